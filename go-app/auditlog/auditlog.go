@@ -0,0 +1,191 @@
+// Package auditlog records one structured entry per proxied request and
+// fans it out to one or more pluggable sinks (rotating file, stdout,
+// Elasticsearch bulk). It's wired in as an http.Handler middleware so every
+// route gets uniform coverage instead of ad-hoc log.Printf calls scattered
+// through the handlers.
+package auditlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entry is one audited request/response pair.
+type Entry struct {
+	Timestamp     string  `json:"timestamp"`
+	Method        string  `json:"method"`
+	Path          string  `json:"path"`
+	Upstream      string  `json:"upstream"`
+	Status        int     `json:"status"`
+	LatencyMS     float64 `json:"latency_ms"`
+	RequestBytes  int64   `json:"request_bytes"`
+	ResponseBytes int64   `json:"response_bytes"`
+	ClientIP      string  `json:"client_ip"`
+	SourceType    string  `json:"source_type,omitempty"`
+	TextLength    int     `json:"text_length,omitempty"`
+}
+
+// Sink is a destination for audit entries.
+type Sink interface {
+	Write(Entry) error
+}
+
+// Logger fans each entry out to every configured sink and keeps a ring
+// buffer of the most recent entries for GET /audit/tail.
+type Logger struct {
+	sinks []Sink
+
+	mu      sync.Mutex
+	ring    []Entry
+	ringPos int
+	ringLen int
+}
+
+const defaultRingSize = 1000
+
+// New builds a Logger that writes to the given sinks (in order) as each
+// request completes.
+func New(sinks ...Sink) *Logger {
+	return &Logger{
+		sinks: sinks,
+		ring:  make([]Entry, defaultRingSize),
+	}
+}
+
+func (l *Logger) record(e Entry) {
+	l.mu.Lock()
+	l.ring[l.ringPos] = e
+	l.ringPos = (l.ringPos + 1) % len(l.ring)
+	if l.ringLen < len(l.ring) {
+		l.ringLen++
+	}
+	l.mu.Unlock()
+
+	for _, sink := range l.sinks {
+		sink.Write(e)
+	}
+}
+
+// Tail returns the n most recent entries, newest last.
+func (l *Logger) Tail(n int) []Entry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if n <= 0 || n > l.ringLen {
+		n = l.ringLen
+	}
+	out := make([]Entry, 0, n)
+	start := (l.ringPos - n + len(l.ring)) % len(l.ring)
+	for i := 0; i < n; i++ {
+		out = append(out, l.ring[(start+i)%len(l.ring)])
+	}
+	return out
+}
+
+// processMeta is the subset of a /process request body the audit log
+// cares about. Kept local rather than importing the proxy's
+// ProcessingRequest type, so this package has no dependency on the
+// cmd package.
+type processMeta struct {
+	SourceType string `json:"source_type"`
+	Text       string `json:"text"`
+}
+
+// Middleware wraps next so every request it serves produces one audit
+// entry, regardless of which route handled it.
+func (l *Logger) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		var meta processMeta
+		if r.Method == http.MethodPost && r.URL.Path == "/process" {
+			meta = readProcessMeta(r)
+		}
+
+		crw := &countingResponseWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(crw, r)
+
+		l.record(Entry{
+			Timestamp:     start.Format(time.RFC3339Nano),
+			Method:        r.Method,
+			Path:          r.URL.Path,
+			Upstream:      classifyUpstream(r.URL.Path),
+			Status:        crw.status,
+			LatencyMS:     float64(time.Since(start)) / float64(time.Millisecond),
+			RequestBytes:  r.ContentLength,
+			ResponseBytes: crw.bytesWritten,
+			ClientIP:      clientIP(r),
+			SourceType:    meta.SourceType,
+			TextLength:    len(meta.Text),
+		})
+	})
+}
+
+// readProcessMeta buffers r's body to extract source_type/text length,
+// then restores it so the real handler can still read it.
+func readProcessMeta(r *http.Request) processMeta {
+	if r.Body == nil {
+		return processMeta{}
+	}
+	body, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return processMeta{}
+	}
+
+	var meta processMeta
+	json.Unmarshal(body, &meta)
+	return meta
+}
+
+func classifyUpstream(path string) string {
+	switch {
+	case strings.HasPrefix(path, "/api/"):
+		return "ai"
+	case strings.HasPrefix(path, "/process"):
+		return "process"
+	default:
+		return "webui"
+	}
+}
+
+func clientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// countingResponseWriter captures the status code and byte count of a
+// response as it's written.
+type countingResponseWriter struct {
+	http.ResponseWriter
+	status       int
+	bytesWritten int64
+}
+
+func (w *countingResponseWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *countingResponseWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	w.bytesWritten += int64(n)
+	return n, err
+}
+
+// Flush lets the wrapped writer support SSE streaming (e.g. the
+// /process/{job_id}/stream endpoint) through the audit middleware.
+func (w *countingResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
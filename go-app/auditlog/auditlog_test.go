@@ -0,0 +1,321 @@
+package auditlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTailReturnsNewestLastAndWrapsRingBuffer(t *testing.T) {
+	l := &Logger{ring: make([]Entry, 3)}
+
+	for i := 1; i <= 5; i++ {
+		l.record(Entry{Path: fmt.Sprintf("/req%d", i)})
+	}
+
+	got := l.Tail(10) // more than the ring can hold
+	want := []string{"/req3", "/req4", "/req5"}
+	if len(got) != len(want) {
+		t.Fatalf("Tail(10) returned %d entries, want %d: %v", len(got), len(want), got)
+	}
+	for i, path := range want {
+		if got[i].Path != path {
+			t.Errorf("Tail(10)[%d].Path = %q, want %q", i, got[i].Path, path)
+		}
+	}
+}
+
+func TestTailLimitsToRequestedCount(t *testing.T) {
+	l := New()
+	for i := 1; i <= 5; i++ {
+		l.record(Entry{Path: fmt.Sprintf("/req%d", i)})
+	}
+
+	got := l.Tail(2)
+	if len(got) != 2 || got[0].Path != "/req4" || got[1].Path != "/req5" {
+		t.Errorf("Tail(2) = %v, want the last 2 entries newest-last", got)
+	}
+}
+
+func TestMiddlewareRecordsRequestDetails(t *testing.T) {
+	l := New()
+	handler := l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("hi"))
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/process", strings.NewReader(`{"source_type":"url","text":"hello world"}`))
+	req.RemoteAddr = "1.2.3.4:5678"
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	entries := l.Tail(1)
+	if len(entries) != 1 {
+		t.Fatalf("Tail(1) = %d entries, want 1", len(entries))
+	}
+
+	e := entries[0]
+	if e.Method != http.MethodPost || e.Path != "/process" || e.Status != http.StatusCreated {
+		t.Errorf("entry method/path/status = %s %s %d, want %s /process %d", e.Method, e.Path, e.Status, http.MethodPost, http.StatusCreated)
+	}
+	if e.Upstream != "process" {
+		t.Errorf("Upstream = %q, want %q", e.Upstream, "process")
+	}
+	if e.ClientIP != "1.2.3.4" {
+		t.Errorf("ClientIP = %q, want %q", e.ClientIP, "1.2.3.4")
+	}
+	if e.SourceType != "url" {
+		t.Errorf("SourceType = %q, want %q", e.SourceType, "url")
+	}
+	if e.TextLength != len("hello world") {
+		t.Errorf("TextLength = %d, want %d", e.TextLength, len("hello world"))
+	}
+	if e.ResponseBytes != int64(len("hi")) {
+		t.Errorf("ResponseBytes = %d, want %d", e.ResponseBytes, len("hi"))
+	}
+}
+
+func TestMiddlewareRestoresRequestBodyForHandler(t *testing.T) {
+	l := New()
+	const payload = `{"source_type":"url","text":"hello"}`
+
+	var seen string
+	handler := l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		seen = string(body)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/process", strings.NewReader(payload))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if seen != payload {
+		t.Errorf("handler saw body %q, want %q", seen, payload)
+	}
+}
+
+func TestFileSinkRotatesAtMaxBytes(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "audit.log")
+
+	one, _ := json.Marshal(Entry{Path: "/x"})
+	entrySize := int64(len(one)) + 1 // FileSink.Write appends a trailing newline
+
+	sink, err := NewFileSink(path, entrySize+entrySize/2, 5)
+	if err != nil {
+		t.Fatalf("NewFileSink: %v", err)
+	}
+
+	if err := sink.Write(Entry{Path: "/x"}); err != nil {
+		t.Fatalf("Write 1: %v", err)
+	}
+	if err := sink.Write(Entry{Path: "/x"}); err != nil {
+		t.Fatalf("Write 2: %v", err)
+	}
+
+	matches, err := filepath.Glob(path + ".*")
+	if err != nil {
+		t.Fatalf("Glob: %v", err)
+	}
+	if len(matches) != 1 {
+		t.Fatalf("rotated file count = %d, want 1 (matches=%v)", len(matches), matches)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile current log: %v", err)
+	}
+	if got := strings.Count(string(data), "\n"); got != 1 {
+		t.Errorf("current log has %d lines, want 1 (the post-rotation write)", got)
+	}
+}
+
+func TestFileSinkPrunesOldBackupsBeyondMaxBackups(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "audit.log")
+
+	one, _ := json.Marshal(Entry{Path: "/x"})
+	entrySize := int64(len(one)) + 1
+
+	sink, err := NewFileSink(path, entrySize, 2) // rotates on nearly every write, keeps 2 backups
+	if err != nil {
+		t.Fatalf("NewFileSink: %v", err)
+	}
+
+	for i := 0; i < 6; i++ {
+		if err := sink.Write(Entry{Path: "/x"}); err != nil {
+			t.Fatalf("Write %d: %v", i, err)
+		}
+		time.Sleep(2 * time.Millisecond) // rotated filenames are timestamp-suffixed
+	}
+
+	matches, err := filepath.Glob(path + ".*")
+	if err != nil {
+		t.Fatalf("Glob: %v", err)
+	}
+	if len(matches) != 2 {
+		t.Errorf("backup count = %d, want 2 (matches=%v)", len(matches), matches)
+	}
+}
+
+func TestElasticsearchSinkFlushesAutomaticallyAtBatchSize(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	sink := &ElasticsearchSink{
+		url:    server.URL + "/_bulk",
+		index:  "audit",
+		client: server.Client(),
+		stopCh: make(chan struct{}),
+	}
+
+	for i := 0; i < defaultBulkFlushSize-1; i++ {
+		if err := sink.Write(Entry{Path: "/x"}); err != nil {
+			t.Fatalf("Write %d: %v", i, err)
+		}
+	}
+	if got := atomic.LoadInt32(&requests); got != 0 {
+		t.Fatalf("bulk request sent before the batch reached its flush size: %d requests", got)
+	}
+
+	if err := sink.Write(Entry{Path: "/x"}); err != nil {
+		t.Fatalf("Write (batch-filling): %v", err)
+	}
+	if got := atomic.LoadInt32(&requests); got != 1 {
+		t.Errorf("requests after filling the batch = %d, want 1", got)
+	}
+}
+
+func TestElasticsearchSinkFlushSendsBulkNdjson(t *testing.T) {
+	var requests int32
+	var body []byte
+	var contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		body, _ = io.ReadAll(r.Body)
+		contentType = r.Header.Get("Content-Type")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	sink := &ElasticsearchSink{
+		url:    server.URL + "/_bulk",
+		index:  "audit",
+		client: server.Client(),
+		stopCh: make(chan struct{}),
+	}
+	sink.Write(Entry{Path: "/a"})
+	sink.Write(Entry{Path: "/b"})
+
+	if err := sink.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	if contentType != "application/x-ndjson" {
+		t.Errorf("Content-Type = %q, want application/x-ndjson", contentType)
+	}
+
+	lines := strings.Split(strings.TrimRight(string(body), "\n"), "\n")
+	if len(lines) != 4 { // one meta line + one doc line per entry, 2 entries
+		t.Fatalf("bulk body has %d lines, want 4:\n%s", len(lines), body)
+	}
+
+	var meta map[string]map[string]string
+	if err := json.Unmarshal([]byte(lines[0]), &meta); err != nil {
+		t.Fatalf("unmarshal meta line: %v", err)
+	}
+	if meta["index"]["_index"] != "audit" {
+		t.Errorf("meta _index = %q, want %q", meta["index"]["_index"], "audit")
+	}
+
+	// The buffer was drained by the first Flush, so a second call with
+	// nothing queued must not send another bulk request.
+	if err := sink.Flush(); err != nil {
+		t.Fatalf("second Flush: %v", err)
+	}
+	if got := atomic.LoadInt32(&requests); got != 1 {
+		t.Errorf("requests after a Flush on an empty buffer = %d, want 1 total", got)
+	}
+}
+
+func TestElasticsearchSinkFlushReturnsErrorOnServerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	sink := &ElasticsearchSink{
+		url:    server.URL + "/_bulk",
+		index:  "audit",
+		client: server.Client(),
+		stopCh: make(chan struct{}),
+	}
+	sink.Write(Entry{Path: "/a"})
+
+	if err := sink.Flush(); err == nil {
+		t.Error("Flush: want an error on a 500 response, got nil")
+	}
+}
+
+func TestElasticsearchSinkFlushLoopFlushesPeriodically(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	sink := &ElasticsearchSink{
+		url:    server.URL + "/_bulk",
+		index:  "audit",
+		client: server.Client(),
+		stopCh: make(chan struct{}),
+	}
+	sink.Write(Entry{Path: "/a"}) // well under defaultBulkFlushSize, wouldn't flush on its own
+
+	go sink.flushLoop(10 * time.Millisecond)
+	defer close(sink.stopCh)
+
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(&requests) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if atomic.LoadInt32(&requests) == 0 {
+		t.Fatal("flushLoop did not flush the buffered entry within 1s of a 10ms interval")
+	}
+}
+
+func TestElasticsearchSinkCloseFlushesRemainingEntries(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	sink := &ElasticsearchSink{
+		url:    server.URL + "/_bulk",
+		index:  "audit",
+		client: server.Client(),
+		stopCh: make(chan struct{}),
+	}
+	sink.Write(Entry{Path: "/a"})
+
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if got := atomic.LoadInt32(&requests); got != 1 {
+		t.Errorf("requests after Close = %d, want 1", got)
+	}
+}
@@ -0,0 +1,250 @@
+package auditlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StdoutSink writes one JSON line per entry to stdout.
+type StdoutSink struct {
+	mu sync.Mutex
+}
+
+func NewStdoutSink() *StdoutSink {
+	return &StdoutSink{}
+}
+
+func (s *StdoutSink) Write(e Entry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = fmt.Fprintln(os.Stdout, string(data))
+	return err
+}
+
+const (
+	DefaultMaxFileBytes = 50 * 1024 * 1024 // 50MB, à la lumberjack's default
+	DefaultMaxBackups   = 5
+)
+
+// FileSink writes one JSON line per entry to a local file, rotating it by
+// size and keeping at most maxBackups rotated copies around.
+type FileSink struct {
+	mu         sync.Mutex
+	path       string
+	maxBytes   int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+// NewFileSink opens (or creates) path for appending, rotating it first if
+// it's already past maxBytes.
+func NewFileSink(path string, maxBytes int64, maxBackups int) (*FileSink, error) {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxFileBytes
+	}
+	if maxBackups <= 0 {
+		maxBackups = DefaultMaxBackups
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("auditlog: failed to create log dir: %w", err)
+		}
+	}
+
+	sink := &FileSink{path: path, maxBytes: maxBytes, maxBackups: maxBackups}
+	if err := sink.open(); err != nil {
+		return nil, err
+	}
+	return sink, nil
+}
+
+func (f *FileSink) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("auditlog: failed to open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("auditlog: failed to stat log file: %w", err)
+	}
+
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+func (f *FileSink) Write(e Entry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.size+int64(len(data)) > f.maxBytes {
+		if err := f.rotate(); err != nil {
+			log.Printf("auditlog: rotation failed, continuing with current file: %v", err)
+		}
+	}
+
+	n, err := f.file.Write(data)
+	f.size += int64(n)
+	return err
+}
+
+func (f *FileSink) rotate() error {
+	f.file.Close()
+
+	rotated := fmt.Sprintf("%s.%s", f.path, time.Now().Format("20060102T150405.000000000"))
+	if err := os.Rename(f.path, rotated); err != nil {
+		f.open() // best-effort: keep logging to the original path
+		return err
+	}
+
+	f.pruneBackups()
+	return f.open()
+}
+
+func (f *FileSink) pruneBackups() {
+	matches, err := filepath.Glob(f.path + ".*")
+	if err != nil || len(matches) <= f.maxBackups {
+		return
+	}
+
+	sort.Strings(matches) // timestamp suffix sorts chronologically
+	for _, old := range matches[:len(matches)-f.maxBackups] {
+		os.Remove(old)
+	}
+}
+
+const (
+	defaultBulkFlushSize     = 100
+	defaultBulkFlushInterval = 5 * time.Second
+)
+
+// ElasticsearchSink batches entries and forwards them to an
+// Elasticsearch/OpenSearch `_bulk` endpoint.
+type ElasticsearchSink struct {
+	url    string
+	index  string
+	client *http.Client
+
+	mu     sync.Mutex
+	buffer []Entry
+
+	stopCh chan struct{}
+}
+
+// NewElasticsearchSink targets the `_bulk` endpoint at baseURL, indexing
+// every entry into index. A background goroutine flushes whatever's
+// buffered every defaultBulkFlushInterval, so a deployment that never
+// reaches defaultBulkFlushSize entries still ships them promptly instead
+// of holding them in memory (and losing them on restart) indefinitely.
+func NewElasticsearchSink(baseURL, index string) *ElasticsearchSink {
+	sink := &ElasticsearchSink{
+		url:    strings.TrimRight(baseURL, "/") + "/_bulk",
+		index:  index,
+		client: &http.Client{Timeout: 10 * time.Second},
+		stopCh: make(chan struct{}),
+	}
+	go sink.flushLoop(defaultBulkFlushInterval)
+	return sink
+}
+
+func (e *ElasticsearchSink) flushLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := e.Flush(); err != nil {
+				log.Printf("auditlog: periodic flush failed: %v", err)
+			}
+		case <-e.stopCh:
+			return
+		}
+	}
+}
+
+// Close stops the periodic flush loop and flushes whatever's left
+// buffered. Safe to call once at shutdown.
+func (e *ElasticsearchSink) Close() error {
+	close(e.stopCh)
+	return e.Flush()
+}
+
+func (e *ElasticsearchSink) Write(entry Entry) error {
+	e.mu.Lock()
+	e.buffer = append(e.buffer, entry)
+	full := len(e.buffer) >= defaultBulkFlushSize
+	e.mu.Unlock()
+
+	if full {
+		return e.Flush()
+	}
+	return nil
+}
+
+// Flush forces any buffered entries out immediately, regardless of batch
+// size.
+func (e *ElasticsearchSink) Flush() error {
+	e.mu.Lock()
+	batch := e.buffer
+	e.buffer = nil
+	e.mu.Unlock()
+
+	if len(batch) == 0 {
+		return nil
+	}
+
+	var body bytes.Buffer
+	for _, entry := range batch {
+		meta, _ := json.Marshal(map[string]interface{}{
+			"index": map[string]string{"_index": e.index},
+		})
+		body.Write(meta)
+		body.WriteByte('\n')
+
+		line, err := json.Marshal(entry)
+		if err != nil {
+			continue
+		}
+		body.Write(line)
+		body.WriteByte('\n')
+	}
+
+	req, err := http.NewRequest(http.MethodPost, e.url, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("auditlog: bulk request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("auditlog: bulk request returned %d", resp.StatusCode)
+	}
+	return nil
+}
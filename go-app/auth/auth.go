@@ -0,0 +1,240 @@
+// Package auth implements API-key authentication, scope checks, and
+// per-key rate limiting for the proxy. Keys are persisted to disk so they
+// survive a restart, and a short-lived session cookie lets the Web UI ride
+// on a key without putting it in every request.
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Scope gates what an API key is allowed to do.
+type Scope string
+
+const (
+	ScopeRead  Scope = "read"
+	ScopeWrite Scope = "write"
+	ScopeAdmin Scope = "admin"
+)
+
+// Quota bounds how hard a key can drive the proxy. Only requests/minute is
+// enforced (see tokenBucket); a tokens/day budget would need something
+// upstream to report each response's token count, which nothing does yet,
+// so it isn't modeled here.
+type Quota struct {
+	RequestsPerMinute int `json:"requests_per_minute"`
+}
+
+// APIKey is a single issued credential.
+type APIKey struct {
+	Key       string    `json:"key"`
+	Scopes    []Scope   `json:"scopes"`
+	Quota     Quota     `json:"quota"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (k *APIKey) HasScope(scope Scope) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultSessionTTL is how long a Web UI session cookie stays valid after
+// being exchanged for an API key.
+const DefaultSessionTTL = 24 * time.Hour
+
+// SessionCookieName is the cookie the Web UI presents after exchanging an
+// API key via HandleSessionExchange.
+const SessionCookieName = "kg_session"
+
+type session struct {
+	key       string
+	expiresAt time.Time
+}
+
+// Store is the in-memory, disk-backed catalog of API keys, their rate
+// limiters, and live Web UI sessions.
+type Store struct {
+	path   string
+	bypass bool
+
+	mu       sync.RWMutex
+	keys     map[string]*APIKey
+	limiters map[string]*tokenBucket
+	sessions map[string]session
+}
+
+// NewStore loads keys from path (creating it, and an initial admin key,
+// if it doesn't exist yet). When bypass is true every auth check is
+// skipped, for local development.
+func NewStore(path string, bypass bool) (*Store, error) {
+	s := &Store{
+		path:     path,
+		bypass:   bypass,
+		keys:     make(map[string]*APIKey),
+		limiters: make(map[string]*tokenBucket),
+		sessions: make(map[string]session),
+	}
+
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	if len(s.keys) == 0 {
+		admin, err := s.Create([]Scope{ScopeRead, ScopeWrite, ScopeAdmin}, Quota{RequestsPerMinute: 600})
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("auth: no API keys found, created initial admin key: %s", admin.Key)
+	}
+
+	return s, nil
+}
+
+func (s *Store) load() error {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("auth: failed to read key store: %w", err)
+	}
+
+	var keys []*APIKey
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return fmt.Errorf("auth: failed to parse key store: %w", err)
+	}
+	for _, k := range keys {
+		s.keys[k.Key] = k
+	}
+	return nil
+}
+
+// save snapshots the key list under lock, then writes it to disk without
+// holding the lock.
+func (s *Store) save() error {
+	s.mu.RLock()
+	keys := make([]*APIKey, 0, len(s.keys))
+	for _, k := range s.keys {
+		keys = append(keys, k)
+	}
+	s.mu.RUnlock()
+
+	data, err := json.MarshalIndent(keys, "", "  ")
+	if err != nil {
+		return fmt.Errorf("auth: failed to marshal key store: %w", err)
+	}
+	if err := os.WriteFile(s.path, data, 0o600); err != nil {
+		return fmt.Errorf("auth: failed to write key store: %w", err)
+	}
+	return nil
+}
+
+// Create issues a new key with the given scopes and quota, persisting it.
+func (s *Store) Create(scopes []Scope, quota Quota) (*APIKey, error) {
+	key := &APIKey{
+		Key:       generateKey(),
+		Scopes:    scopes,
+		Quota:     quota,
+		CreatedAt: time.Now(),
+	}
+
+	s.mu.Lock()
+	s.keys[key.Key] = key
+	s.mu.Unlock()
+
+	if err := s.save(); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Get looks up a key by its raw value.
+func (s *Store) Get(key string) (*APIKey, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	k, ok := s.keys[key]
+	return k, ok
+}
+
+// List returns every issued key.
+func (s *Store) List() []*APIKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]*APIKey, 0, len(s.keys))
+	for _, k := range s.keys {
+		out = append(out, k)
+	}
+	return out
+}
+
+func (s *Store) limiterFor(key *APIKey) *tokenBucket {
+	s.mu.RLock()
+	lim, ok := s.limiters[key.Key]
+	s.mu.RUnlock()
+	if ok {
+		return lim
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if lim, ok := s.limiters[key.Key]; ok {
+		return lim
+	}
+	lim = newTokenBucket(key.Quota.RequestsPerMinute)
+	s.limiters[key.Key] = lim
+	return lim
+}
+
+// CreateSession exchanges an API key for a short-lived session ID.
+func (s *Store) CreateSession(key *APIKey) string {
+	id := generateKey()
+	s.mu.Lock()
+	s.sessions[id] = session{key: key.Key, expiresAt: time.Now().Add(DefaultSessionTTL)}
+	s.mu.Unlock()
+	return id
+}
+
+// SessionKey resolves a session ID back to its API key, if it's still
+// valid.
+func (s *Store) SessionKey(sessionID string) (*APIKey, bool) {
+	s.mu.Lock()
+	sess, ok := s.sessions[sessionID]
+	if ok && time.Now().After(sess.expiresAt) {
+		delete(s.sessions, sessionID)
+		ok = false
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		return nil, false
+	}
+	return s.Get(sess.key)
+}
+
+func generateKey() string {
+	buf := make([]byte, 20)
+	rand.Read(buf)
+	return "kg_" + hex.EncodeToString(buf)
+}
+
+func bearerToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return ""
+	}
+	return strings.TrimPrefix(header, "Bearer ")
+}
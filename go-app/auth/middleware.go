@@ -0,0 +1,132 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// RequireBearer wraps next so it only runs for callers presenting a valid
+// API key with the given scope, rate-limited per key. Skipped entirely
+// when the store is in bypass mode.
+func (s *Store) RequireBearer(scope Scope, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.bypass {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token := bearerToken(r)
+		if token == "" {
+			http.Error(w, "Missing API key", http.StatusUnauthorized)
+			return
+		}
+
+		key, ok := s.Get(token)
+		if !ok {
+			http.Error(w, "Invalid API key", http.StatusUnauthorized)
+			return
+		}
+		if !key.HasScope(scope) {
+			http.Error(w, "Insufficient scope", http.StatusForbidden)
+			return
+		}
+
+		if allowed, retryAfter := s.limiterFor(key).Allow(); !allowed {
+			w.Header().Set("Retry-After", fmt.Sprintf("%.0f", retryAfter.Seconds()))
+			http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireSession wraps next so it only runs for callers with a valid
+// session cookie exchanged from an API key via HandleSessionExchange.
+// Skipped entirely when the store is in bypass mode.
+func (s *Store) RequireSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.bypass {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cookie, err := r.Cookie(SessionCookieName)
+		if err != nil {
+			http.Error(w, "Missing session", http.StatusUnauthorized)
+			return
+		}
+		if _, ok := s.SessionKey(cookie.Value); !ok {
+			http.Error(w, "Invalid or expired session", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// HandleSessionExchange implements POST /auth/session: trade an API key
+// for a session cookie the Web UI can ride on.
+func (s *Store) HandleSessionExchange(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body struct {
+		Key string `json:"key"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Key == "" {
+		http.Error(w, "Missing key", http.StatusBadRequest)
+		return
+	}
+
+	key, ok := s.Get(body.Key)
+	if !ok {
+		http.Error(w, "Invalid API key", http.StatusUnauthorized)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookieName,
+		Value:    s.CreateSession(key),
+		Path:     "/",
+		HttpOnly: true,
+		MaxAge:   int(DefaultSessionTTL.Seconds()),
+	})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleAdminKeys implements GET/POST /admin/keys. Callers are expected to
+// have already been checked for ScopeAdmin via RequireBearer.
+func (s *Store) HandleAdminKeys(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": s.List()})
+
+	case http.MethodPost:
+		var req struct {
+			Scopes []Scope `json:"scopes"`
+			Quota  Quota   `json:"quota"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		key, err := s.Create(req.Scopes, req.Quota)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(key)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func newTestStore(t *testing.T, bypass bool) *Store {
+	t.Helper()
+	s, err := NewStore(filepath.Join(t.TempDir(), "keys.json"), bypass)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	return s
+}
+
+func okHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func TestRequireBearerMissingToken(t *testing.T) {
+	s := newTestStore(t, false)
+	req := httptest.NewRequest(http.MethodGet, "/api/x", nil)
+	rec := httptest.NewRecorder()
+
+	s.RequireBearer(ScopeRead, okHandler()).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestRequireBearerInvalidToken(t *testing.T) {
+	s := newTestStore(t, false)
+	req := httptest.NewRequest(http.MethodGet, "/api/x", nil)
+	req.Header.Set("Authorization", "Bearer not-a-real-key")
+	rec := httptest.NewRecorder()
+
+	s.RequireBearer(ScopeRead, okHandler()).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestRequireBearerInsufficientScope(t *testing.T) {
+	s := newTestStore(t, false)
+	key, err := s.Create([]Scope{ScopeRead}, Quota{RequestsPerMinute: 60})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/x", nil)
+	req.Header.Set("Authorization", "Bearer "+key.Key)
+	rec := httptest.NewRecorder()
+
+	s.RequireBearer(ScopeWrite, okHandler()).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+}
+
+func TestRequireBearerAllowsSufficientScope(t *testing.T) {
+	s := newTestStore(t, false)
+	key, err := s.Create([]Scope{ScopeRead, ScopeWrite}, Quota{RequestsPerMinute: 60})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/x", nil)
+	req.Header.Set("Authorization", "Bearer "+key.Key)
+	rec := httptest.NewRecorder()
+
+	s.RequireBearer(ScopeWrite, okHandler()).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestRequireBearerRateLimitsPerKey(t *testing.T) {
+	s := newTestStore(t, false)
+	key, err := s.Create([]Scope{ScopeRead}, Quota{RequestsPerMinute: 1})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	handler := s.RequireBearer(ScopeRead, okHandler())
+	do := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/api/x", nil)
+		req.Header.Set("Authorization", "Bearer "+key.Key)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := do(); code != http.StatusOK {
+		t.Fatalf("first request: status = %d, want %d", code, http.StatusOK)
+	}
+	if code := do(); code != http.StatusTooManyRequests {
+		t.Fatalf("second request: status = %d, want %d", code, http.StatusTooManyRequests)
+	}
+}
+
+func TestRequireBearerBypassSkipsChecks(t *testing.T) {
+	s := newTestStore(t, true)
+	req := httptest.NewRequest(http.MethodGet, "/api/x", nil)
+	rec := httptest.NewRecorder()
+
+	s.RequireBearer(ScopeAdmin, okHandler()).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d (bypass mode)", rec.Code, http.StatusOK)
+	}
+}
@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// tokenBucket is a minimal token-bucket rate limiter: capacity and refill
+// rate both come from the key's requests-per-minute quota, so a key can
+// burst up to one minute's allowance before it starts throttling.
+type tokenBucket struct {
+	mu           sync.Mutex
+	capacity     float64
+	tokens       float64
+	refillPerSec float64
+	last         time.Time
+}
+
+func newTokenBucket(requestsPerMinute int) *tokenBucket {
+	if requestsPerMinute <= 0 {
+		requestsPerMinute = 60
+	}
+	capacity := float64(requestsPerMinute)
+	return &tokenBucket{
+		capacity:     capacity,
+		tokens:       capacity,
+		refillPerSec: capacity / 60,
+		last:         time.Now(),
+	}
+}
+
+// Allow reports whether a request may proceed. If not, it also returns how
+// long the caller should wait before retrying.
+func (b *tokenBucket) Allow() (bool, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	elapsed := now.Sub(b.last).Seconds()
+	b.last = now
+	b.tokens = math.Min(b.capacity, b.tokens+elapsed*b.refillPerSec)
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	deficit := 1 - b.tokens
+	wait := time.Duration(deficit / b.refillPerSec * float64(time.Second))
+	return false, wait
+}
@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTokenBucketAllowsUpToCapacityThenThrottles(t *testing.T) {
+	b := newTokenBucket(5)
+
+	for i := 0; i < 5; i++ {
+		if allowed, _ := b.Allow(); !allowed {
+			t.Fatalf("request %d: Allow() = false, want true (capacity not yet exhausted)", i)
+		}
+	}
+
+	allowed, wait := b.Allow()
+	if allowed {
+		t.Fatalf("Allow() = true after exhausting capacity, want false")
+	}
+	if wait <= 0 {
+		t.Errorf("wait = %v, want > 0", wait)
+	}
+}
+
+func TestTokenBucketRefillsOverTime(t *testing.T) {
+	b := newTokenBucket(60)
+	for i := 0; i < 60; i++ {
+		if allowed, _ := b.Allow(); !allowed {
+			t.Fatalf("request %d: Allow() = false before capacity exhausted", i)
+		}
+	}
+	if allowed, _ := b.Allow(); allowed {
+		t.Fatalf("Allow() = true with no tokens left")
+	}
+
+	// Simulate a minute having passed since the last call, which at 60
+	// requests/minute should fully refill the bucket.
+	b.last = b.last.Add(-time.Minute)
+
+	if allowed, _ := b.Allow(); !allowed {
+		t.Fatalf("Allow() = false after a full refill interval elapsed")
+	}
+}
+
+func TestNewTokenBucketDefaultsNonPositiveRate(t *testing.T) {
+	b := newTokenBucket(0)
+	if b.capacity != 60 {
+		t.Errorf("capacity = %v, want 60 for non-positive requestsPerMinute", b.capacity)
+	}
+}
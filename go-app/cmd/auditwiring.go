@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/ichiyama3ize/KnowledgeGlow/go-app/auditlog"
+)
+
+// newAuditLogger builds the audit Logger from environment config:
+//
+//   - AUDIT_LOG_FILE (default "./audit.log"), AUDIT_LOG_MAX_BYTES,
+//     AUDIT_LOG_MAX_BACKUPS configure the rotating file sink.
+//   - AUDIT_LOG_STDOUT=true additionally mirrors entries to stdout.
+//   - AUDIT_LOG_ES_URL / AUDIT_LOG_ES_INDEX, if both set, forward entries
+//     to an Elasticsearch/OpenSearch _bulk endpoint.
+func newAuditLogger() *auditlog.Logger {
+	var sinks []auditlog.Sink
+
+	filePath := getEnvString("AUDIT_LOG_FILE", "./audit.log")
+	maxBytes := int64(getEnvInt("AUDIT_LOG_MAX_BYTES", auditlog.DefaultMaxFileBytes))
+	maxBackups := getEnvInt("AUDIT_LOG_MAX_BACKUPS", auditlog.DefaultMaxBackups)
+	if fileSink, err := auditlog.NewFileSink(filePath, maxBytes, maxBackups); err != nil {
+		log.Printf("Failed to open audit log file %q, falling back to stdout only: %v", filePath, err)
+	} else {
+		sinks = append(sinks, fileSink)
+	}
+
+	if os.Getenv("AUDIT_LOG_STDOUT") == "true" {
+		sinks = append(sinks, auditlog.NewStdoutSink())
+	}
+
+	if esURL, esIndex := os.Getenv("AUDIT_LOG_ES_URL"), os.Getenv("AUDIT_LOG_ES_INDEX"); esURL != "" && esIndex != "" {
+		sinks = append(sinks, auditlog.NewElasticsearchSink(esURL, esIndex))
+	}
+
+	if len(sinks) == 0 {
+		sinks = append(sinks, auditlog.NewStdoutSink())
+	}
+
+	return auditlog.New(sinks...)
+}
+
+// handleAuditTail implements GET /audit/tail?n=100.
+func handleAuditTail(w http.ResponseWriter, r *http.Request, audit *auditlog.Logger) {
+	n := 100
+	if raw := r.URL.Query().Get("n"); raw != "" {
+		if parsed, err := strconv.Atoi(raw); err == nil {
+			n = parsed
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"entries": audit.Tail(n),
+	})
+}
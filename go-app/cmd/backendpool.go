@@ -0,0 +1,387 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// BalanceStrategy selects which backend serves the next request.
+type BalanceStrategy string
+
+const (
+	StrategyRoundRobin    BalanceStrategy = "round-robin"
+	StrategyLeastConn     BalanceStrategy = "least-connections"
+	StrategyRandom        BalanceStrategy = "random"
+	DefaultHealthInterval                 = 10 * time.Second
+	DefaultHealthTimeout                  = 3 * time.Second
+
+	// DefaultForwardTimeout bounds a single Forward call. AI processing can
+	// run long, so this is generous compared to the health-check timeout.
+	DefaultForwardTimeout = 5 * time.Minute
+)
+
+// Backend is a single AI worker instance behind the pool.
+type Backend struct {
+	URL         *url.URL
+	Proxy       *httputil.ReverseProxy
+	healthy     atomic.Bool
+	activeConns int64
+}
+
+func (b *Backend) Healthy() bool {
+	return b.healthy.Load()
+}
+
+func (b *Backend) setHealthy(v bool) {
+	if b.healthy.Swap(v) != v && v {
+		log.Printf("Backend %s recovered, returning to rotation", b.URL)
+	} else if !v {
+		log.Printf("Backend %s marked unhealthy, removed from rotation", b.URL)
+	}
+}
+
+// BackendPool load-balances requests across a set of Python AI backends and
+// tracks their health in the background.
+type BackendPool struct {
+	mu       sync.Mutex
+	backends []*Backend
+	strategy BalanceStrategy
+	rrIndex  uint64
+
+	healthInterval time.Duration
+	healthTimeout  time.Duration
+	healthClient   *http.Client
+	forwardClient  *http.Client
+
+	stopCh chan struct{}
+}
+
+// NewBackendPool builds a pool from a list of raw "host:port" addresses.
+// healthInterval and healthTimeout fall back to their package defaults when
+// zero, so callers without a configured value can pass them through as-is.
+func NewBackendPool(addrs []string, strategy BalanceStrategy, healthInterval, healthTimeout time.Duration) (*BackendPool, error) {
+	backends, err := buildBackends(addrs)
+	if err != nil {
+		return nil, err
+	}
+	if len(backends) == 0 {
+		return nil, fmt.Errorf("backend pool requires at least one backend")
+	}
+	if healthInterval <= 0 {
+		healthInterval = DefaultHealthInterval
+	}
+	if healthTimeout <= 0 {
+		healthTimeout = DefaultHealthTimeout
+	}
+	if healthTimeout > healthInterval {
+		// A timeout longer than the tick interval lets checkAll pile up
+		// overlapping in-flight requests per backend faster than they can
+		// time out, so clamp it to the interval rather than honoring it.
+		healthTimeout = healthInterval
+	}
+
+	return &BackendPool{
+		backends:       backends,
+		strategy:       strategy,
+		healthInterval: healthInterval,
+		healthTimeout:  healthTimeout,
+		healthClient:   &http.Client{Timeout: healthTimeout},
+		forwardClient:  &http.Client{Timeout: DefaultForwardTimeout},
+		stopCh:         make(chan struct{}),
+	}, nil
+}
+
+// buildBackends turns raw "host:port" (or full URL) addresses into Backend
+// instances, each with its own reverse proxy and an initially-healthy state.
+func buildBackends(addrs []string) ([]*Backend, error) {
+	var backends []*Backend
+	for _, addr := range addrs {
+		addr = strings.TrimSpace(addr)
+		if addr == "" {
+			continue
+		}
+		if !strings.Contains(addr, "://") {
+			addr = "http://" + addr
+		}
+		parsed, err := url.Parse(addr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid backend address %q: %w", addr, err)
+		}
+		backend := &Backend{
+			URL:   parsed,
+			Proxy: httputil.NewSingleHostReverseProxy(parsed),
+		}
+		backend.healthy.Store(true)
+		backends = append(backends, backend)
+	}
+	return backends, nil
+}
+
+// SetAddrs atomically replaces the pool's backend list, e.g. when the
+// service registry reports a changed set of live instances. Addresses
+// already in the pool keep their health state; new ones start healthy.
+func (p *BackendPool) SetAddrs(addrs []string) error {
+	fresh, err := buildBackends(addrs)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	existing := make(map[string]*Backend, len(p.backends))
+	for _, b := range p.backends {
+		existing[b.URL.String()] = b
+	}
+	for i, b := range fresh {
+		if old, ok := existing[b.URL.String()]; ok {
+			fresh[i] = old
+		}
+	}
+	p.backends = fresh
+	return nil
+}
+
+// Start launches the background health-checker. Call Stop to halt it.
+func (p *BackendPool) Start() {
+	go p.healthCheckLoop()
+}
+
+func (p *BackendPool) Stop() {
+	close(p.stopCh)
+}
+
+func (p *BackendPool) healthCheckLoop() {
+	ticker := time.NewTicker(p.healthInterval)
+	defer ticker.Stop()
+
+	p.checkAll()
+	for {
+		select {
+		case <-ticker.C:
+			p.checkAll()
+		case <-p.stopCh:
+			return
+		}
+	}
+}
+
+// snapshotBackends returns a copy of the current backend list, safe to
+// range over without holding the lock.
+func (p *BackendPool) snapshotBackends() []*Backend {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := make([]*Backend, len(p.backends))
+	copy(out, p.backends)
+	return out
+}
+
+func (p *BackendPool) checkAll() {
+	for _, b := range p.snapshotBackends() {
+		go p.checkOne(b)
+	}
+}
+
+func (p *BackendPool) checkOne(b *Backend) {
+	healthURL := fmt.Sprintf("%s/health", strings.TrimRight(b.URL.String(), "/"))
+	resp, err := p.healthClient.Get(healthURL)
+	if err != nil || resp.StatusCode >= 500 {
+		b.setHealthy(false)
+		if resp != nil {
+			resp.Body.Close()
+		}
+		return
+	}
+	resp.Body.Close()
+	b.setHealthy(true)
+}
+
+// healthyBackends returns the currently in-rotation backends.
+func (p *BackendPool) healthyBackends() []*Backend {
+	var healthy []*Backend
+	for _, b := range p.snapshotBackends() {
+		if b.Healthy() {
+			healthy = append(healthy, b)
+		}
+	}
+	return healthy
+}
+
+// Next picks the next backend to serve a request according to the pool's
+// strategy, skipping unhealthy backends. It reports false if the pool
+// currently has no backends at all — e.g. a SetAddrs call (driven by a
+// registry change) racing with a caller that already passed its own
+// zero-backend guard — so callers can fail the request instead of
+// indexing into an empty slice.
+func (p *BackendPool) Next() (*Backend, bool) {
+	healthy := p.healthyBackends()
+	if len(healthy) == 0 {
+		// Every backend looks unhealthy; fall back to the full set rather
+		// than failing outright, since the health check may itself be wrong.
+		healthy = p.snapshotBackends()
+	}
+	if len(healthy) == 0 {
+		return nil, false
+	}
+
+	switch p.strategy {
+	case StrategyRandom:
+		return healthy[rand.Intn(len(healthy))], true
+	case StrategyLeastConn:
+		best := healthy[0]
+		for _, b := range healthy[1:] {
+			if atomic.LoadInt64(&b.activeConns) < atomic.LoadInt64(&best.activeConns) {
+				best = b
+			}
+		}
+		return best, true
+	default: // StrategyRoundRobin
+		idx := atomic.AddUint64(&p.rrIndex, 1)
+		return healthy[int(idx)%len(healthy)], true
+	}
+}
+
+// ServeHTTP proxies the request to a healthy backend, retrying the next
+// healthy backend on connection failure or a 5xx response. The retry
+// decision is made from ModifyResponse, which runs after headers come back
+// but before anything is copied to w, so a response that passes never
+// touches memory beyond whatever the backend/client are doing on their own
+// — it streams straight through instead of being buffered whole.
+func (p *BackendPool) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	attempts := len(p.snapshotBackends())
+	if attempts == 0 {
+		http.Error(w, "no AI backends configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	var body []byte
+	if r.Body != nil {
+		body, _ = readAllAndClose(r.Body)
+	}
+
+	tried := make(map[*Backend]bool)
+	for i := 0; i < attempts; i++ {
+		backend, ok := p.Next()
+		if !ok || tried[backend] {
+			break
+		}
+		tried[backend] = true
+
+		r.Body = newRewindableBody(body)
+
+		proxy := *backend.Proxy
+		failed := false
+		proxy.ModifyResponse = func(resp *http.Response) error {
+			if resp.StatusCode >= 500 {
+				return fmt.Errorf("backend %s returned %d", backend.URL, resp.StatusCode)
+			}
+			return nil
+		}
+		proxy.ErrorHandler = func(http.ResponseWriter, *http.Request, error) {
+			// Fires for a dial failure or a ModifyResponse rejection, in
+			// both cases before anything has been written to w, so it's
+			// still safe to fall through and try the next backend.
+			failed = true
+		}
+
+		atomic.AddInt64(&backend.activeConns, 1)
+		proxy.ServeHTTP(w, r)
+		atomic.AddInt64(&backend.activeConns, -1)
+
+		if !failed {
+			return
+		}
+		backend.setHealthy(false)
+	}
+
+	http.Error(w, "AI service unavailable", http.StatusServiceUnavailable)
+}
+
+// Forward makes a direct client call against a healthy backend, retrying
+// the next healthy backend on connection failure or a 5xx response. Unlike
+// ServeHTTP it isn't tied to an in-flight http.ResponseWriter, so it's the
+// entry point background job workers use to call the AI service.
+func (p *BackendPool) Forward(method, path string, body []byte) (*http.Response, error) {
+	healthy := p.healthyBackends()
+	attempts := len(healthy)
+	if attempts == 0 {
+		attempts = len(p.snapshotBackends())
+	}
+	if attempts == 0 {
+		return nil, fmt.Errorf("no AI backends configured")
+	}
+
+	tried := make(map[*Backend]bool)
+	var lastErr error
+	for i := 0; i < attempts; i++ {
+		backend, ok := p.Next()
+		if !ok || tried[backend] {
+			break
+		}
+		tried[backend] = true
+
+		target := strings.TrimRight(backend.URL.String(), "/") + path
+		req, err := http.NewRequest(method, target, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		atomic.AddInt64(&backend.activeConns, 1)
+		resp, err := p.forwardClient.Do(req)
+		atomic.AddInt64(&backend.activeConns, -1)
+
+		if err != nil {
+			backend.setHealthy(false)
+			lastErr = err
+			continue
+		}
+		if resp.StatusCode >= 500 {
+			backend.setHealthy(false)
+			lastErr = fmt.Errorf("backend %s returned %d", backend.URL, resp.StatusCode)
+			resp.Body.Close()
+			continue
+		}
+		return resp, nil
+	}
+
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no healthy backends")
+	}
+	return nil, fmt.Errorf("AI service unavailable: %w", lastErr)
+}
+
+// poolSummary collapses a pool's per-backend status into the single
+// healthy/unhealthy string the aggregate /health response has always used.
+func poolSummary(pool *BackendPool) string {
+	if len(pool.healthyBackends()) == 0 {
+		return "unhealthy"
+	}
+	return "healthy"
+}
+
+// Status reports per-backend health for the /health endpoint.
+func (p *BackendPool) Status() []map[string]interface{} {
+	backends := p.snapshotBackends()
+	status := make([]map[string]interface{}, 0, len(backends))
+	for _, b := range backends {
+		state := "unhealthy"
+		if b.Healthy() {
+			state = "healthy"
+		}
+		status = append(status, map[string]interface{}{
+			"url":    b.URL.String(),
+			"status": state,
+		})
+	}
+	return status
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestPool builds a pool with a health interval/timeout long enough that
+// the background checker (never started in these tests anyway) couldn't
+// interfere, and fails the test immediately on a construction error.
+func newTestPool(t *testing.T, addrs []string, strategy BalanceStrategy) *BackendPool {
+	t.Helper()
+	pool, err := NewBackendPool(addrs, strategy, time.Hour, time.Hour)
+	if err != nil {
+		t.Fatalf("NewBackendPool: %v", err)
+	}
+	return pool
+}
+
+func TestServeHTTPRetriesOnDialFailureThenServesHealthyBackend(t *testing.T) {
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("ok"))
+	}))
+	defer up.Close()
+
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	downAddr := down.URL
+	down.Close() // now refuses connections, simulating a crashed worker
+
+	// up first, down second: round-robin's first pick lands on index 1, so
+	// this ordering guarantees the down backend is the one actually tried.
+	pool := newTestPool(t, []string{up.URL, downAddr}, StrategyRoundRobin)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/x", nil)
+	rec := httptest.NewRecorder()
+	pool.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d; body=%s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	if rec.Body.String() != "ok" {
+		t.Errorf("body = %q, want %q", rec.Body.String(), "ok")
+	}
+
+	if downBackend := findBackend(t, pool, downAddr); downBackend.Healthy() {
+		t.Errorf("down backend still marked healthy after a failed attempt")
+	}
+}
+
+func TestServeHTTPRetriesOn5xxThenServesHealthyBackend(t *testing.T) {
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("ok"))
+	}))
+	defer up.Close()
+
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer broken.Close()
+
+	// up first, broken second: round-robin's first pick lands on index 1, so
+	// this ordering guarantees the broken backend is the one actually tried.
+	pool := newTestPool(t, []string{up.URL, broken.URL}, StrategyRoundRobin)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/x", nil)
+	rec := httptest.NewRecorder()
+	pool.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d; body=%s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+
+	if brokenBackend := findBackend(t, pool, broken.URL); brokenBackend.Healthy() {
+		t.Errorf("backend that returned 500 still marked healthy")
+	}
+}
+
+func TestServeHTTPReturnsServiceUnavailableWhenAllBackendsFail(t *testing.T) {
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	downAddr := down.URL
+	down.Close()
+
+	pool := newTestPool(t, []string{downAddr}, StrategyRoundRobin)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/x", nil)
+	rec := httptest.NewRecorder()
+	pool.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+}
+
+func TestServeHTTPReturnsServiceUnavailableWhenPoolEmpty(t *testing.T) {
+	pool := newTestPool(t, []string{"127.0.0.1:0"}, StrategyRoundRobin)
+	if err := pool.SetAddrs(nil); err != nil {
+		t.Fatalf("SetAddrs: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/x", nil)
+	rec := httptest.NewRecorder()
+	pool.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+}
+
+func TestNextReturnsFalseWhenPoolEmpty(t *testing.T) {
+	pool := newTestPool(t, []string{"127.0.0.1:0"}, StrategyRoundRobin)
+	if err := pool.SetAddrs(nil); err != nil {
+		t.Fatalf("SetAddrs: %v", err)
+	}
+
+	if _, ok := pool.Next(); ok {
+		t.Errorf("Next() = ok on an empty pool, want false")
+	}
+}
+
+func TestNextRoundRobinCyclesThroughBackends(t *testing.T) {
+	pool := newTestPool(t, []string{"host1:1", "host2:2"}, StrategyRoundRobin)
+
+	first, ok := pool.Next()
+	if !ok {
+		t.Fatal("Next() = !ok")
+	}
+	second, ok := pool.Next()
+	if !ok {
+		t.Fatal("Next() = !ok")
+	}
+	third, ok := pool.Next()
+	if !ok {
+		t.Fatal("Next() = !ok")
+	}
+
+	if first == second {
+		t.Errorf("round-robin picked the same backend twice in a row")
+	}
+	if first != third {
+		t.Errorf("round-robin did not cycle back to the first backend on the third call")
+	}
+}
+
+func TestNextLeastConnPicksFewestActiveConns(t *testing.T) {
+	pool := newTestPool(t, []string{"host1:1", "host2:2"}, StrategyLeastConn)
+
+	backends := pool.snapshotBackends()
+	atomic.AddInt64(&backends[0].activeConns, 5)
+
+	picked, ok := pool.Next()
+	if !ok {
+		t.Fatal("Next() = !ok")
+	}
+	if picked != backends[1] {
+		t.Errorf("least-conn picked %s, want the backend with fewer active connections", picked.URL)
+	}
+}
+
+func TestBackendSetHealthyTransitions(t *testing.T) {
+	b := &Backend{}
+	b.healthy.Store(true)
+	if !b.Healthy() {
+		t.Fatal("expected healthy initially")
+	}
+
+	b.setHealthy(false)
+	if b.Healthy() {
+		t.Error("expected unhealthy after setHealthy(false)")
+	}
+
+	b.setHealthy(true)
+	if !b.Healthy() {
+		t.Error("expected healthy again after setHealthy(true)")
+	}
+}
+
+func findBackend(t *testing.T, pool *BackendPool, rawURL string) *Backend {
+	t.Helper()
+	for _, b := range pool.snapshotBackends() {
+		if b.URL.String() == rawURL {
+			return b
+		}
+	}
+	t.Fatalf("backend %s not found in pool", rawURL)
+	return nil
+}
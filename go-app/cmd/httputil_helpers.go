@@ -0,0 +1,19 @@
+package main
+
+import (
+	"bytes"
+	"io"
+)
+
+// readAllAndClose drains and closes r, returning whatever bytes were read
+// even if an error occurred partway through.
+func readAllAndClose(r io.ReadCloser) ([]byte, error) {
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// rewindableBody lets the same request body be replayed across retry
+// attempts against different backends.
+func newRewindableBody(body []byte) io.ReadCloser {
+	return io.NopCloser(bytes.NewReader(body))
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ichiyama3ize/KnowledgeGlow/go-app/queue"
+)
+
+// jobStreamPollInterval is how often handleJobStream re-checks a job's
+// status while waiting for it to finish.
+const jobStreamPollInterval = 500 * time.Millisecond
+
+// handleProcess dispatches every /process route: POST /process enqueues a
+// job, GET /process/{job_id} polls its status, and GET
+// /process/{job_id}/stream streams status updates via Server-Sent Events.
+func handleProcess(w http.ResponseWriter, r *http.Request, q *queue.Queue) {
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/process"), "/")
+
+	switch {
+	case rest == "" && r.Method == http.MethodPost:
+		handleEnqueueJob(w, r, q)
+	case r.Method == http.MethodGet && strings.HasSuffix(rest, "/stream"):
+		handleJobStream(w, r, q, strings.TrimSuffix(rest, "/stream"))
+	case r.Method == http.MethodGet && rest != "":
+		handleJobStatus(w, q, rest)
+	default:
+		http.Error(w, "Not found", http.StatusNotFound)
+	}
+}
+
+func handleEnqueueJob(w http.ResponseWriter, r *http.Request, q *queue.Queue) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+
+	job, err := q.Enqueue(body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to queue job: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{
+		"job_id": job.ID,
+		"status": string(job.Status),
+	})
+}
+
+func handleJobStatus(w http.ResponseWriter, q *queue.Queue, jobID string) {
+	job, ok := q.Get(jobID)
+	if !ok {
+		http.Error(w, "Job not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
+
+func handleJobStream(w http.ResponseWriter, r *http.Request, q *queue.Queue, jobID string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	ticker := time.NewTicker(jobStreamPollInterval)
+	defer ticker.Stop()
+
+	for {
+		job, ok := q.Get(jobID)
+		if !ok {
+			fmt.Fprintf(w, "event: error\ndata: job not found\n\n")
+			flusher.Flush()
+			return
+		}
+
+		data, _ := json.Marshal(job)
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		flusher.Flush()
+
+		if job.Status == queue.StatusDone || job.Status == queue.StatusFailed {
+			return
+		}
+
+		select {
+		case <-ticker.C:
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/ichiyama3ize/KnowledgeGlow/go-app/queue"
+)
+
+const (
+	DefaultAIWorkers      = 4
+	DefaultMaxJobAttempts = 5
+	processAPIPath        = "/api/process"
+)
+
+// startJobWorkers launches count goroutines that pull jobs off q and
+// forward them to the Python AI pool, retrying failures with exponential
+// backoff up to maxAttempts before giving up on a job.
+func startJobWorkers(q *queue.Queue, pool *BackendPool, count, maxAttempts int) {
+	for i := 0; i < count; i++ {
+		go jobWorkerLoop(q, pool, maxAttempts)
+	}
+}
+
+func jobWorkerLoop(q *queue.Queue, pool *BackendPool, maxAttempts int) {
+	for {
+		job, ok := q.Next()
+		if !ok {
+			return
+		}
+		processJob(q, pool, job, maxAttempts)
+	}
+}
+
+func processJob(q *queue.Queue, pool *BackendPool, job *queue.Job, maxAttempts int) {
+	resp, err := pool.Forward(http.MethodPost, processAPIPath, job.Payload)
+	if err != nil {
+		retryOrFail(q, job, maxAttempts, err.Error())
+		return
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		retryOrFail(q, job, maxAttempts, fmt.Sprintf("failed to read AI service response: %v", err))
+		return
+	}
+
+	if resp.StatusCode >= 500 {
+		retryOrFail(q, job, maxAttempts, fmt.Sprintf("AI service returned %d", resp.StatusCode))
+		return
+	}
+
+	if err := q.Complete(job.ID, body); err != nil {
+		log.Printf("Failed to persist completed job %s: %v", job.ID, err)
+	}
+}
+
+// retryOrFail schedules an exponential backoff retry, or marks the job
+// permanently failed once it has exhausted maxAttempts.
+func retryOrFail(q *queue.Queue, job *queue.Job, maxAttempts int, reason string) {
+	if job.Attempts >= maxAttempts {
+		log.Printf("Job %s failed permanently after %d attempts: %s", job.ID, job.Attempts, reason)
+		if err := q.Fail(job.ID, reason); err != nil {
+			log.Printf("Failed to persist failed job %s: %v", job.ID, err)
+		}
+		return
+	}
+
+	backoff := time.Duration(math.Pow(2, float64(job.Attempts))) * time.Second
+	log.Printf("Job %s attempt %d failed: %s — retrying in %s", job.ID, job.Attempts, reason, backoff)
+	time.AfterFunc(backoff, func() {
+		if err := q.Requeue(job.ID); err != nil {
+			log.Printf("Failed to requeue job %s: %v", job.ID, err)
+		}
+	})
+}
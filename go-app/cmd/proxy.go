@@ -1,18 +1,18 @@
 package main
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
-	"net/http/httputil"
-	"net/url"
 	"os"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/ichiyama3ize/KnowledgeGlow/go-app/auth"
+	"github.com/ichiyama3ize/KnowledgeGlow/go-app/queue"
+	"github.com/ichiyama3ize/KnowledgeGlow/go-app/registry"
 )
 
 // Configuration
@@ -30,11 +30,11 @@ type ProcessingRequest struct {
 }
 
 type ProcessingResponse struct {
-	Summary    string   `json:"summary"`
-	Tags       []string `json:"tags"`
-	Analysis   string   `json:"analysis"`
-	Status     string   `json:"status"`
-	Error      string   `json:"error,omitempty"`
+	Summary  string   `json:"summary"`
+	Tags     []string `json:"tags"`
+	Analysis string   `json:"analysis"`
+	Status   string   `json:"status"`
+	Error    string   `json:"error,omitempty"`
 }
 
 type KnowledgeItem struct {
@@ -56,22 +56,91 @@ func main() {
 	pythonPort := getEnvInt("PYTHON_PORT", DefaultPythonPort)
 	webUIPort := getEnvInt("WEBUI_PORT", DefaultWebUIPort)
 
-	// Create reverse proxy for Python AI service
-	pythonURL, err := url.Parse(fmt.Sprintf("http://localhost:%d", pythonPort))
+	// The registry is the single source of truth for which backends are
+	// live. Static PYTHON_PORT/PYTHON_BACKENDS/WEBUI_PORT config is seeded
+	// into it as self-heartbeating entries, and any worker or alternate UI
+	// that self-registers joins the same pool without a proxy restart.
+	reg := registry.New(registry.DefaultHeartbeatTTL)
+	reg.StartEviction()
+	bootstrapStaticBackends(reg, pythonPort, webUIPort)
+
+	pythonHealthInterval := getEnvDuration("PYTHON_HEALTH_INTERVAL", DefaultHealthInterval)
+	pythonHealthTimeout := getEnvDuration("PYTHON_HEALTH_TIMEOUT", DefaultHealthTimeout)
+
+	pythonPool, err := NewBackendPool(reg.URLs("ai"), resolvePythonStrategy(), pythonHealthInterval, pythonHealthTimeout)
+	if err != nil {
+		log.Fatal("Failed to build Python backend pool:", err)
+	}
+	pythonPool.Start()
+
+	webUIPool, err := NewBackendPool(reg.URLs("webui"), StrategyRoundRobin, DefaultHealthInterval, DefaultHealthTimeout)
+	if err != nil {
+		log.Fatal("Failed to build Web UI backend pool:", err)
+	}
+	webUIPool.Start()
+
+	// /process jobs are queued to disk and worked off by a pool of
+	// background goroutines so a slow or crashing AI worker can't hold an
+	// HTTP request open for the duration of processing.
+	queueDir := getEnvString("QUEUE_DIR", "./queue_data")
+	jobQueue, err := queue.Open(queueDir)
 	if err != nil {
-		log.Fatal("Failed to parse Python service URL:", err)
+		log.Fatal("Failed to open job queue:", err)
 	}
-	pythonProxy := httputil.NewSingleHostReverseProxy(pythonURL)
+	aiWorkers := getEnvInt("AI_WORKERS", DefaultAIWorkers)
+	maxJobAttempts := getEnvInt("MAX_JOB_ATTEMPTS", DefaultMaxJobAttempts)
+	startJobWorkers(jobQueue, pythonPool, aiWorkers, maxJobAttempts)
+
+	reg.OnChange(func(name string) {
+		switch name {
+		case "ai":
+			if err := pythonPool.SetAddrs(reg.URLs("ai")); err != nil {
+				log.Printf("Failed to refresh ai backend pool: %v", err)
+			}
+		case "webui":
+			if err := webUIPool.SetAddrs(reg.URLs("webui")); err != nil {
+				log.Printf("Failed to refresh webui backend pool: %v", err)
+			}
+		}
+	})
+
+	audit := newAuditLogger()
 
-	// Create reverse proxy for Web UI
-	webUIURL, err := url.Parse(fmt.Sprintf("http://localhost:%d", webUIPort))
+	authStore, err := auth.NewStore(getEnvString("AUTH_KEYS_FILE", "./api_keys.json"), os.Getenv("AUTH_BYPASS") == "true")
 	if err != nil {
-		log.Fatal("Failed to parse Web UI URL:", err)
+		log.Fatal("Failed to load auth store:", err)
 	}
-	webUIProxy := httputil.NewSingleHostReverseProxy(webUIURL)
+
+	// /api/* and /process require a bearer API key (write scope for
+	// mutating methods, read scope otherwise); the Web UI rides on a
+	// session cookie exchanged from one instead. /health stays open so
+	// orchestrators can probe it without a key.
+	aiReadHandler := authStore.RequireBearer(auth.ScopeRead, pythonPool)
+	aiWriteHandler := authStore.RequireBearer(auth.ScopeWrite, pythonPool)
+	processReadHandler := authStore.RequireBearer(auth.ScopeRead, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handleProcess(w, r, jobQueue)
+	}))
+	processWriteHandler := authStore.RequireBearer(auth.ScopeWrite, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handleProcess(w, r, jobQueue)
+	}))
+	webUIHandler := authStore.RequireSession(webUIPool)
+
+	// The registry controls which backends receive live /process traffic
+	// and the audit log holds client IPs and request text, so both are as
+	// privileged as /admin/keys and gated the same way.
+	mux := http.NewServeMux()
+	mux.Handle("/registry/register", authStore.RequireBearer(auth.ScopeAdmin, http.HandlerFunc(reg.HandleRegister)))
+	mux.Handle("/registry/deregister/", authStore.RequireBearer(auth.ScopeAdmin, http.HandlerFunc(reg.HandleDeregister)))
+	mux.Handle("/registry/heartbeat/", authStore.RequireBearer(auth.ScopeAdmin, http.HandlerFunc(reg.HandleHeartbeat)))
+	mux.Handle("/registry/services", authStore.RequireBearer(auth.ScopeAdmin, http.HandlerFunc(reg.HandleServices)))
+	mux.Handle("/audit/tail", authStore.RequireBearer(auth.ScopeAdmin, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handleAuditTail(w, r, audit)
+	})))
+	mux.HandleFunc("/auth/session", authStore.HandleSessionExchange)
+	mux.Handle("/admin/keys", authStore.RequireBearer(auth.ScopeAdmin, http.HandlerFunc(authStore.HandleAdminKeys)))
 
 	// Setup routes
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		// Enable CORS
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
@@ -84,26 +153,32 @@ func main() {
 
 		// Route API requests to Python service
 		if strings.HasPrefix(r.URL.Path, "/api/") {
-			log.Printf("Proxying API request: %s %s", r.Method, r.URL.Path)
-			pythonProxy.ServeHTTP(w, r)
+			if isMutatingMethod(r.Method) {
+				aiWriteHandler.ServeHTTP(w, r)
+			} else {
+				aiReadHandler.ServeHTTP(w, r)
+			}
 			return
 		}
 
 		// Route AI processing requests
 		if strings.HasPrefix(r.URL.Path, "/process") {
-			handleAIProcessing(w, r, pythonPort)
+			if r.Method == http.MethodPost {
+				processWriteHandler.ServeHTTP(w, r)
+			} else {
+				processReadHandler.ServeHTTP(w, r)
+			}
 			return
 		}
 
 		// Health check
 		if r.URL.Path == "/health" {
-			handleHealthCheck(w, r, pythonPort, webUIPort)
+			handleHealthCheck(w, r, pythonPool, webUIPool)
 			return
 		}
 
 		// Serve Web UI for all other requests
-		log.Printf("Proxying UI request: %s %s", r.Method, r.URL.Path)
-		webUIProxy.ServeHTTP(w, r)
+		webUIHandler.ServeHTTP(w, r)
 	})
 
 	log.Printf("🚀 KnowledgeGlow Proxy Server starting on port %d", goPort)
@@ -111,64 +186,23 @@ func main() {
 	log.Printf("🌐 Proxying Web UI on port %d", webUIPort)
 	log.Printf("🔗 Access the application at: http://localhost:%d", goPort)
 
-	if err := http.ListenAndServe(fmt.Sprintf(":%d", goPort), nil); err != nil {
+	if err := http.ListenAndServe(fmt.Sprintf(":%d", goPort), audit.Middleware(mux)); err != nil {
 		log.Fatal("Server failed to start:", err)
 	}
 }
 
-func handleAIProcessing(w http.ResponseWriter, r *http.Request, pythonPort int) {
-	if r.Method != "POST" {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	// Read request body
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
-		http.Error(w, "Failed to read request body", http.StatusBadRequest)
-		return
-	}
-
-	// Forward to Python AI service
-	pythonURL := fmt.Sprintf("http://localhost:%d/api/process", pythonPort)
-	resp, err := http.Post(pythonURL, "application/json", bytes.NewBuffer(body))
-	if err != nil {
-		log.Printf("Error forwarding to Python service: %v", err)
-		http.Error(w, "AI service unavailable", http.StatusServiceUnavailable)
-		return
-	}
-	defer resp.Body.Close()
-
-	// Copy response
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(resp.StatusCode)
-	io.Copy(w, resp.Body)
-}
-
-func handleHealthCheck(w http.ResponseWriter, r *http.Request, pythonPort, webUIPort int) {
+func handleHealthCheck(w http.ResponseWriter, r *http.Request, pythonPool, webUIPool *BackendPool) {
 	health := map[string]interface{}{
 		"status":    "healthy",
 		"timestamp": time.Now().Format(time.RFC3339),
 		"services":  map[string]string{},
 	}
 
-	// Check Python AI service
-	pythonURL := fmt.Sprintf("http://localhost:%d/health", pythonPort)
-	if resp, err := http.Get(pythonURL); err == nil && resp.StatusCode == 200 {
-		health["services"].(map[string]string)["ai_service"] = "healthy"
-		resp.Body.Close()
-	} else {
-		health["services"].(map[string]string)["ai_service"] = "unhealthy"
-	}
-
-	// Check Web UI service
-	webUIURL := fmt.Sprintf("http://localhost:%d/health", webUIPort)
-	if resp, err := http.Get(webUIURL); err == nil && resp.StatusCode == 200 {
-		health["services"].(map[string]string)["web_ui"] = "healthy"
-		resp.Body.Close()
-	} else {
-		health["services"].(map[string]string)["web_ui"] = "unhealthy"
-	}
+	// Per-backend status for both pools
+	health["services"].(map[string]string)["ai_service"] = poolSummary(pythonPool)
+	health["services"].(map[string]string)["web_ui"] = poolSummary(webUIPool)
+	health["ai_backends"] = pythonPool.Status()
+	health["webui_backends"] = webUIPool.Status()
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(health)
@@ -181,4 +215,33 @@ func getEnvInt(key string, defaultValue int) int {
 		}
 	}
 	return defaultValue
-}
\ No newline at end of file
+}
+
+// getEnvDuration reads key as a whole number of seconds, falling back to
+// defaultValue if it's unset or unparsable.
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+	return defaultValue
+}
+
+func getEnvString(key string, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// isMutatingMethod reports whether method changes state and should
+// therefore require write scope rather than read scope.
+func isMutatingMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	default:
+		return true
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ichiyama3ize/KnowledgeGlow/go-app/registry"
+)
+
+// bootstrapStaticBackends seeds the registry with the backends configured
+// via environment variables (PYTHON_PORT/PYTHON_BACKENDS, WEBUI_PORT) so
+// they show up in GET /registry/services and participate in load-balancing
+// exactly like a self-registered worker, without requiring an operator to
+// register anything by hand for the common single-box setup. Each entry is
+// kept alive with its own heartbeat goroutine for the lifetime of the
+// process.
+func bootstrapStaticBackends(reg *registry.Registry, pythonPort, webUIPort int) {
+	aiAddrs := []string{fmt.Sprintf("localhost:%d", pythonPort)}
+	if raw := os.Getenv("PYTHON_BACKENDS"); raw != "" {
+		aiAddrs = strings.Split(raw, ",")
+	}
+	for _, addr := range aiAddrs {
+		bootstrapOne(reg, "ai", addr)
+	}
+
+	webUIAddrs := []string{fmt.Sprintf("localhost:%d", webUIPort)}
+	if raw := os.Getenv("WEBUI_BACKENDS"); raw != "" {
+		webUIAddrs = strings.Split(raw, ",")
+	}
+	for _, addr := range webUIAddrs {
+		bootstrapOne(reg, "webui", addr)
+	}
+}
+
+func bootstrapOne(reg *registry.Registry, name, addr string) {
+	addr = strings.TrimSpace(addr)
+	if addr == "" {
+		return
+	}
+	if !strings.Contains(addr, "://") {
+		addr = "http://" + addr
+	}
+
+	svc, err := reg.Register(registry.RegisterRequest{
+		Name:           name,
+		URL:            addr,
+		HealthEndpoint: "/health",
+	})
+	if err != nil {
+		log.Printf("Failed to bootstrap static %s backend %s: %v", name, addr, err)
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(registry.DefaultHeartbeatTTL / 3)
+		defer ticker.Stop()
+		for range ticker.C {
+			reg.Heartbeat(svc.ID)
+		}
+	}()
+}
+
+// resolvePythonStrategy reads PYTHON_BALANCE_STRATEGY, defaulting to
+// round-robin for anything unset or unrecognized.
+func resolvePythonStrategy() BalanceStrategy {
+	strategy := BalanceStrategy(os.Getenv("PYTHON_BALANCE_STRATEGY"))
+	switch strategy {
+	case StrategyRoundRobin, StrategyLeastConn, StrategyRandom:
+		return strategy
+	default:
+		return StrategyRoundRobin
+	}
+}
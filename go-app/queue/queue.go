@@ -0,0 +1,257 @@
+// Package queue implements a persistent, on-disk FIFO job queue. Jobs
+// survive a proxy restart: anything left in the "queued" or "processing"
+// state when the process exits is replayed, in submission order, the next
+// time Open is called against the same directory.
+package queue
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Status is the lifecycle state of a Job.
+type Status string
+
+const (
+	StatusQueued     Status = "queued"
+	StatusProcessing Status = "processing"
+	StatusDone       Status = "done"
+	StatusFailed     Status = "failed"
+)
+
+// Job is one unit of work. Payload and Result are kept as raw JSON so the
+// queue stays agnostic of the caller's request/response shapes.
+type Job struct {
+	ID        string          `json:"job_id"`
+	Payload   json.RawMessage `json:"payload"`
+	Status    Status          `json:"status"`
+	Result    json.RawMessage `json:"result,omitempty"`
+	Error     string          `json:"error,omitempty"`
+	Attempts  int             `json:"attempts"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// Queue is a persistent FIFO of Jobs backed by one JSON file per job under
+// dir. An in-memory, unbounded slice of ready job IDs drives dispatch to
+// worker goroutines: Enqueue/Requeue/the startup replay all append to it
+// without blocking, and Next blocks on a condition variable rather than a
+// fixed-capacity channel, so a backlog of any size can't deadlock the
+// producer (an HTTP handler) or the startup replay.
+type Queue struct {
+	dir string
+
+	mu     sync.Mutex
+	cond   *sync.Cond
+	jobs   map[string]*Job
+	ready  []string
+	closed bool
+}
+
+// Open loads (or creates) the on-disk queue at dir and replays any job
+// left queued or mid-processing from a previous run, oldest first.
+func Open(dir string) (*Queue, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("queue: failed to create queue dir: %w", err)
+	}
+
+	q := &Queue{
+		dir:  dir,
+		jobs: make(map[string]*Job),
+	}
+	q.cond = sync.NewCond(&q.mu)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("queue: failed to read queue dir: %w", err)
+	}
+
+	var unfinished []*Job
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		var job Job
+		if err := json.Unmarshal(data, &job); err != nil {
+			continue
+		}
+		q.jobs[job.ID] = &job
+		if job.Status == StatusQueued || job.Status == StatusProcessing {
+			unfinished = append(unfinished, &job)
+		}
+	}
+
+	sort.Slice(unfinished, func(i, j int) bool {
+		return unfinished[i].CreatedAt.Before(unfinished[j].CreatedAt)
+	})
+	for _, job := range unfinished {
+		job.Status = StatusQueued
+		q.persist(job)
+		q.pushReady(job.ID)
+	}
+
+	return q, nil
+}
+
+// Enqueue persists a new job and makes it available to the next free
+// worker, returning immediately.
+func (q *Queue) Enqueue(payload []byte) (*Job, error) {
+	now := time.Now()
+	job := &Job{
+		ID:        generateID(),
+		Payload:   json.RawMessage(payload),
+		Status:    StatusQueued,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	q.mu.Lock()
+	q.jobs[job.ID] = job
+	q.mu.Unlock()
+
+	if err := q.persist(job); err != nil {
+		return nil, err
+	}
+	q.pushReady(job.ID)
+	return job, nil
+}
+
+// pushReady appends id to the ready queue and wakes one waiting Next call.
+// Unlike a send on a fixed-capacity channel, this never blocks.
+func (q *Queue) pushReady(id string) {
+	q.mu.Lock()
+	q.ready = append(q.ready, id)
+	q.mu.Unlock()
+	q.cond.Signal()
+}
+
+// Next blocks until a job is ready, claims it (marking it processing and
+// counting the attempt), and returns it. It returns false if the queue was
+// closed while waiting.
+func (q *Queue) Next() (*Job, bool) {
+	q.mu.Lock()
+	for len(q.ready) == 0 && !q.closed {
+		q.cond.Wait()
+	}
+	if len(q.ready) == 0 {
+		q.mu.Unlock()
+		return nil, false
+	}
+
+	id := q.ready[0]
+	q.ready = q.ready[1:]
+
+	job, exists := q.jobs[id]
+	if exists {
+		job.Status = StatusProcessing
+		job.Attempts++
+		job.UpdatedAt = time.Now()
+	}
+	q.mu.Unlock()
+
+	if !exists {
+		return nil, true
+	}
+	q.persist(job)
+	return job, true
+}
+
+// Close stops any worker blocked in Next, causing it to return (nil, false).
+func (q *Queue) Close() {
+	q.mu.Lock()
+	q.closed = true
+	q.mu.Unlock()
+	q.cond.Broadcast()
+}
+
+// Complete marks a job done with its result payload.
+func (q *Queue) Complete(id string, result []byte) error {
+	return q.update(id, func(job *Job) {
+		job.Status = StatusDone
+		job.Result = json.RawMessage(result)
+		job.Error = ""
+	})
+}
+
+// Fail marks a job permanently failed after it has exhausted its retries.
+func (q *Queue) Fail(id string, reason string) error {
+	return q.update(id, func(job *Job) {
+		job.Status = StatusFailed
+		job.Error = reason
+	})
+}
+
+// Requeue puts a job back at the end of the ready queue for another
+// attempt, without resetting its attempt count.
+func (q *Queue) Requeue(id string) error {
+	if err := q.update(id, func(job *Job) {
+		job.Status = StatusQueued
+	}); err != nil {
+		return err
+	}
+	q.pushReady(id)
+	return nil
+}
+
+// Get returns a snapshot of a job by ID.
+func (q *Queue) Get(id string) (*Job, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, ok := q.jobs[id]
+	if !ok {
+		return nil, false
+	}
+	copied := *job
+	return &copied, true
+}
+
+func (q *Queue) update(id string, mutate func(*Job)) error {
+	q.mu.Lock()
+	job, ok := q.jobs[id]
+	if ok {
+		mutate(job)
+		job.UpdatedAt = time.Now()
+	}
+	q.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("queue: job %q not found", id)
+	}
+	return q.persist(job)
+}
+
+// persist writes a job's current state to disk, via a temp-file-plus-rename
+// so a crash mid-write can't leave a corrupt record behind.
+func (q *Queue) persist(job *Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("queue: failed to marshal job %s: %w", job.ID, err)
+	}
+
+	final := filepath.Join(q.dir, job.ID+".json")
+	tmp := final + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("queue: failed to write job %s: %w", job.ID, err)
+	}
+	if err := os.Rename(tmp, final); err != nil {
+		return fmt.Errorf("queue: failed to finalize job %s: %w", job.ID, err)
+	}
+	return nil
+}
+
+func generateID() string {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return "job_" + hex.EncodeToString(buf)
+}
@@ -0,0 +1,157 @@
+package queue
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeJobFile(t *testing.T, dir string, job Job) {
+	t.Helper()
+	data, err := json.Marshal(job)
+	if err != nil {
+		t.Fatalf("marshal job: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, job.ID+".json"), data, 0o644); err != nil {
+		t.Fatalf("write job file: %v", err)
+	}
+}
+
+func TestOpenReplaysUnfinishedJobsOldestFirst(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Two finished jobs that must NOT be replayed, and two unfinished jobs
+	// written out of CreatedAt order, to make sure Open sorts by age
+	// rather than by file order.
+	writeJobFile(t, dir, Job{ID: "job_done", Status: StatusDone, CreatedAt: base, UpdatedAt: base})
+	writeJobFile(t, dir, Job{ID: "job_failed", Status: StatusFailed, CreatedAt: base.Add(time.Minute), UpdatedAt: base})
+	writeJobFile(t, dir, Job{ID: "job_newer", Status: StatusQueued, CreatedAt: base.Add(2 * time.Minute), UpdatedAt: base})
+	writeJobFile(t, dir, Job{ID: "job_older", Status: StatusProcessing, CreatedAt: base.Add(time.Second), UpdatedAt: base})
+
+	q, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	var got []string
+	for i := 0; i < 2; i++ {
+		job, ok := q.Next()
+		if !ok {
+			t.Fatalf("Next() returned !ok claiming unfinished job %d", i)
+		}
+		got = append(got, job.ID)
+		if job.Status != StatusProcessing {
+			t.Errorf("job %s: status = %s, want %s", job.ID, job.Status, StatusProcessing)
+		}
+	}
+
+	want := []string{"job_older", "job_newer"}
+	for i, id := range want {
+		if got[i] != id {
+			t.Errorf("replay order[%d] = %s, want %s (got %v)", i, got[i], id, got)
+		}
+	}
+
+	if len(q.ready) != 0 {
+		t.Fatalf("done/failed job was replayed: %v", q.ready)
+	}
+}
+
+func TestOpenLeavesFinishedJobsQueryableButNotReplayed(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Now()
+	writeJobFile(t, dir, Job{ID: "job_done", Status: StatusDone, Result: json.RawMessage(`{"ok":true}`), CreatedAt: base, UpdatedAt: base})
+
+	q, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	job, ok := q.Get("job_done")
+	if !ok {
+		t.Fatalf("Get(job_done): not found")
+	}
+	if job.Status != StatusDone {
+		t.Errorf("job_done status = %s, want %s", job.Status, StatusDone)
+	}
+
+	if len(q.ready) != 0 {
+		t.Fatalf("finished job was enqueued for replay: %v", q.ready)
+	}
+}
+
+// TestEnqueueNeverBlocksOnBacklogSize guards against a regression to the
+// old fixed-capacity-channel dispatch, where enqueuing more jobs than the
+// channel's buffer without a worker draining them would hang forever.
+func TestEnqueueNeverBlocksOnBacklogSize(t *testing.T) {
+	q, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	const backlog = 1500 // more than the old channel's 1024 capacity
+	done := make(chan error, 1)
+	go func() {
+		for i := 0; i < backlog; i++ {
+			if _, err := q.Enqueue([]byte(`{}`)); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Enqueue: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Enqueue of %d jobs with no worker draining them did not return — backlog is blocking the caller again", backlog)
+	}
+
+	if len(q.ready) != backlog {
+		t.Errorf("ready backlog = %d, want %d", len(q.ready), backlog)
+	}
+}
+
+func TestEnqueueNextComplete(t *testing.T) {
+	q, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	job, err := q.Enqueue([]byte(`{"text":"hello"}`))
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	if job.Status != StatusQueued {
+		t.Fatalf("new job status = %s, want %s", job.Status, StatusQueued)
+	}
+
+	claimed, ok := q.Next()
+	if !ok {
+		t.Fatalf("Next() returned !ok")
+	}
+	if claimed.ID != job.ID {
+		t.Fatalf("Next() returned job %s, want %s", claimed.ID, job.ID)
+	}
+	if claimed.Attempts != 1 {
+		t.Errorf("claimed.Attempts = %d, want 1", claimed.Attempts)
+	}
+
+	if err := q.Complete(job.ID, []byte(`{"summary":"ok"}`)); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+
+	done, ok := q.Get(job.ID)
+	if !ok {
+		t.Fatalf("Get after Complete: not found")
+	}
+	if done.Status != StatusDone {
+		t.Errorf("status after Complete = %s, want %s", done.Status, StatusDone)
+	}
+}
@@ -0,0 +1,286 @@
+// Package registry implements a service registry and heartbeat-based
+// auto-discovery so AI workers and alternate UIs can register themselves
+// with the Go proxy instead of being wired in as static upstreams.
+package registry
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultHeartbeatTTL is how long a service is kept in the catalog without
+// a heartbeat before it's evicted.
+const DefaultHeartbeatTTL = 30 * time.Second
+
+// Service is a single registered backend instance.
+type Service struct {
+	ID             string    `json:"id"`
+	Name           string    `json:"name"`
+	URL            string    `json:"url"`
+	HealthEndpoint string    `json:"health_endpoint,omitempty"`
+	Capabilities   []string  `json:"capabilities,omitempty"`
+	RegisteredAt   time.Time `json:"registered_at"`
+	LastHeartbeat  time.Time `json:"last_heartbeat"`
+}
+
+// RegisterRequest is the payload for POST /registry/register.
+type RegisterRequest struct {
+	Name           string   `json:"name"`
+	URL            string   `json:"url"`
+	HealthEndpoint string   `json:"health_endpoint"`
+	Capabilities   []string `json:"capabilities"`
+}
+
+// Registry is an in-memory catalog of registered services, keyed by ID,
+// with TTL-based eviction driven by periodic heartbeats.
+type Registry struct {
+	mu       sync.RWMutex
+	services map[string]*Service
+	ttl      time.Duration
+	onChange func(name string)
+	stopCh   chan struct{}
+}
+
+// New creates a Registry that evicts a service once it misses heartbeats
+// for longer than ttl.
+func New(ttl time.Duration) *Registry {
+	if ttl <= 0 {
+		ttl = DefaultHeartbeatTTL
+	}
+	return &Registry{
+		services: make(map[string]*Service),
+		ttl:      ttl,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// OnChange registers a callback invoked whenever the set of live services
+// for a given name changes (register, deregister, or eviction). Callers
+// use this to feed fresh backend URLs into a load-balancing pool.
+func (r *Registry) OnChange(fn func(name string)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.onChange = fn
+}
+
+func (r *Registry) notify(name string) {
+	r.mu.RLock()
+	fn := r.onChange
+	r.mu.RUnlock()
+	if fn != nil {
+		fn(name)
+	}
+}
+
+// Register adds a new service to the catalog and returns it with a
+// generated ID and initial heartbeat timestamp.
+func (r *Registry) Register(req RegisterRequest) (*Service, error) {
+	if req.Name == "" || req.URL == "" {
+		return nil, fmt.Errorf("registry: name and url are required")
+	}
+
+	now := r.now()
+	svc := &Service{
+		ID:             generateID(),
+		Name:           req.Name,
+		URL:            req.URL,
+		HealthEndpoint: req.HealthEndpoint,
+		Capabilities:   req.Capabilities,
+		RegisteredAt:   now,
+		LastHeartbeat:  now,
+	}
+
+	r.mu.Lock()
+	r.services[svc.ID] = svc
+	r.mu.Unlock()
+
+	r.notify(svc.Name)
+	return svc, nil
+}
+
+// Deregister removes a service immediately. Returns false if it wasn't
+// found.
+func (r *Registry) Deregister(id string) bool {
+	r.mu.Lock()
+	svc, ok := r.services[id]
+	if ok {
+		delete(r.services, id)
+	}
+	r.mu.Unlock()
+
+	if ok {
+		r.notify(svc.Name)
+	}
+	return ok
+}
+
+// Heartbeat refreshes a service's TTL clock. Returns false if the service
+// isn't registered (e.g. it was already evicted).
+func (r *Registry) Heartbeat(id string) bool {
+	r.mu.Lock()
+	svc, ok := r.services[id]
+	if ok {
+		svc.LastHeartbeat = r.now()
+	}
+	r.mu.Unlock()
+	return ok
+}
+
+// Services returns every currently registered service.
+func (r *Registry) Services() []*Service {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]*Service, 0, len(r.services))
+	for _, svc := range r.services {
+		out = append(out, svc)
+	}
+	return out
+}
+
+// URLs returns the backend URLs of every live service registered under
+// name, e.g. "ai" or "webui".
+func (r *Registry) URLs(name string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var urls []string
+	for _, svc := range r.services {
+		if svc.Name == name {
+			urls = append(urls, svc.URL)
+		}
+	}
+	return urls
+}
+
+// StartEviction launches the background goroutine that evicts services
+// which have missed their heartbeat TTL. Call Stop to halt it.
+func (r *Registry) StartEviction() {
+	go r.evictionLoop()
+}
+
+func (r *Registry) Stop() {
+	close(r.stopCh)
+}
+
+func (r *Registry) evictionLoop() {
+	ticker := time.NewTicker(r.ttl / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.evictExpired()
+		case <-r.stopCh:
+			return
+		}
+	}
+}
+
+func (r *Registry) evictExpired() {
+	deadline := r.now().Add(-r.ttl)
+
+	r.mu.Lock()
+	var evicted []string
+	for id, svc := range r.services {
+		if svc.LastHeartbeat.Before(deadline) {
+			delete(r.services, id)
+			evicted = append(evicted, svc.Name)
+		}
+	}
+	r.mu.Unlock()
+
+	for _, name := range evicted {
+		r.notify(name)
+	}
+}
+
+func (r *Registry) now() time.Time {
+	return time.Now()
+}
+
+func generateID() string {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return "svc_" + hex.EncodeToString(buf)
+}
+
+// HandleRegister implements POST /registry/register.
+func (r *Registry) HandleRegister(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body RegisterRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	svc, err := r.Register(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(svc)
+}
+
+// HandleDeregister implements DELETE /registry/deregister/{id}.
+func (r *Registry) HandleDeregister(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := strings.TrimPrefix(req.URL.Path, "/registry/deregister/")
+	if id == "" {
+		http.Error(w, "Missing service id", http.StatusBadRequest)
+		return
+	}
+
+	if !r.Deregister(id) {
+		http.Error(w, "Service not found", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleHeartbeat implements PATCH /registry/heartbeat/{id}.
+func (r *Registry) HandleHeartbeat(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPatch {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := strings.TrimPrefix(req.URL.Path, "/registry/heartbeat/")
+	if id == "" {
+		http.Error(w, "Missing service id", http.StatusBadRequest)
+		return
+	}
+
+	if !r.Heartbeat(id) {
+		http.Error(w, "Service not found", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// HandleServices implements GET /registry/services.
+func (r *Registry) HandleServices(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"services": r.Services(),
+	})
+}
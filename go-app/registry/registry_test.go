@@ -0,0 +1,250 @@
+package registry
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEvictExpiredRemovesOnlyStaleServices(t *testing.T) {
+	r := New(time.Minute)
+
+	fresh, err := r.Register(RegisterRequest{Name: "ai", URL: "http://fresh:1"})
+	if err != nil {
+		t.Fatalf("Register(fresh): %v", err)
+	}
+	stale, err := r.Register(RegisterRequest{Name: "ai", URL: "http://stale:1"})
+	if err != nil {
+		t.Fatalf("Register(stale): %v", err)
+	}
+
+	// evictExpired has no injectable clock, so backdate the stale service's
+	// heartbeat directly rather than sleeping past the TTL in the test.
+	r.mu.Lock()
+	r.services[stale.ID].LastHeartbeat = time.Now().Add(-r.ttl - time.Second)
+	r.mu.Unlock()
+
+	r.evictExpired()
+
+	if _, ok := r.services[stale.ID]; ok {
+		t.Errorf("stale service %s was not evicted", stale.ID)
+	}
+	if _, ok := r.services[fresh.ID]; !ok {
+		t.Errorf("fresh service %s was evicted, want it kept", fresh.ID)
+	}
+}
+
+func TestEvictExpiredNotifiesOnChangeForEvictedServices(t *testing.T) {
+	r := New(time.Minute)
+	svc, err := r.Register(RegisterRequest{Name: "ai", URL: "http://stale:1"})
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	var notified []string
+	r.OnChange(func(name string) { notified = append(notified, name) })
+
+	r.mu.Lock()
+	r.services[svc.ID].LastHeartbeat = time.Now().Add(-r.ttl - time.Second)
+	r.mu.Unlock()
+
+	r.evictExpired()
+
+	if len(notified) != 1 || notified[0] != "ai" {
+		t.Errorf("OnChange notifications = %v, want [\"ai\"]", notified)
+	}
+}
+
+func TestEvictExpiredIsNoopWhenNothingIsStale(t *testing.T) {
+	r := New(time.Minute)
+	if _, err := r.Register(RegisterRequest{Name: "ai", URL: "http://fresh:1"}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	called := false
+	r.OnChange(func(name string) { called = true })
+
+	r.evictExpired()
+
+	if called {
+		t.Errorf("OnChange fired with nothing stale to evict")
+	}
+	if len(r.Services()) != 1 {
+		t.Errorf("Services() = %d entries, want 1", len(r.Services()))
+	}
+}
+
+func TestRegisterDeregisterNotifyOnChange(t *testing.T) {
+	r := New(time.Minute)
+	var notified []string
+	r.OnChange(func(name string) { notified = append(notified, name) })
+
+	svc, err := r.Register(RegisterRequest{Name: "ai", URL: "http://backend:1"})
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if !r.Deregister(svc.ID) {
+		t.Fatalf("Deregister(%s) = false, want true", svc.ID)
+	}
+	if r.Deregister(svc.ID) {
+		t.Errorf("Deregister of an already-removed service = true, want false")
+	}
+
+	want := []string{"ai", "ai"}
+	if len(notified) != len(want) {
+		t.Fatalf("OnChange notifications = %v, want %v", notified, want)
+	}
+}
+
+func TestRegisterRequiresNameAndURL(t *testing.T) {
+	r := New(time.Minute)
+	if _, err := r.Register(RegisterRequest{Name: "", URL: "http://x:1"}); err == nil {
+		t.Error("Register with empty name: want error, got nil")
+	}
+	if _, err := r.Register(RegisterRequest{Name: "ai", URL: ""}); err == nil {
+		t.Error("Register with empty url: want error, got nil")
+	}
+}
+
+func TestHeartbeatRefreshesLastHeartbeat(t *testing.T) {
+	r := New(time.Minute)
+	svc, err := r.Register(RegisterRequest{Name: "ai", URL: "http://backend:1"})
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	r.mu.Lock()
+	r.services[svc.ID].LastHeartbeat = time.Now().Add(-time.Hour)
+	r.mu.Unlock()
+
+	if !r.Heartbeat(svc.ID) {
+		t.Fatalf("Heartbeat(%s) = false, want true", svc.ID)
+	}
+
+	r.mu.RLock()
+	refreshed := r.services[svc.ID].LastHeartbeat
+	r.mu.RUnlock()
+	if time.Since(refreshed) > time.Second {
+		t.Errorf("LastHeartbeat wasn't refreshed: %v", refreshed)
+	}
+
+	if r.Heartbeat("nonexistent") {
+		t.Errorf("Heartbeat on an unknown id = true, want false")
+	}
+}
+
+func TestHandleRegisterCreatesService(t *testing.T) {
+	r := New(time.Minute)
+	req := httptest.NewRequest(http.MethodPost, "/registry/register", strings.NewReader(`{"name":"ai","url":"http://backend:1"}`))
+	rec := httptest.NewRecorder()
+
+	r.HandleRegister(rec, req)
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d; body=%s", rec.Code, http.StatusCreated, rec.Body.String())
+	}
+	if len(r.Services()) != 1 {
+		t.Errorf("Services() = %d entries, want 1", len(r.Services()))
+	}
+}
+
+func TestHandleRegisterRejectsWrongMethod(t *testing.T) {
+	r := New(time.Minute)
+	req := httptest.NewRequest(http.MethodGet, "/registry/register", nil)
+	rec := httptest.NewRecorder()
+
+	r.HandleRegister(rec, req)
+
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestHandleDeregisterRemovesService(t *testing.T) {
+	r := New(time.Minute)
+	svc, err := r.Register(RegisterRequest{Name: "ai", URL: "http://backend:1"})
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodDelete, "/registry/deregister/"+svc.ID, nil)
+	rec := httptest.NewRecorder()
+	r.HandleDeregister(rec, req)
+
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+	if len(r.Services()) != 0 {
+		t.Errorf("Services() = %d entries, want 0", len(r.Services()))
+	}
+}
+
+func TestHandleDeregisterUnknownIDReturnsNotFound(t *testing.T) {
+	r := New(time.Minute)
+	req := httptest.NewRequest(http.MethodDelete, "/registry/deregister/nonexistent", nil)
+	rec := httptest.NewRecorder()
+	r.HandleDeregister(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestHandleHeartbeatRefreshesService(t *testing.T) {
+	r := New(time.Minute)
+	svc, err := r.Register(RegisterRequest{Name: "ai", URL: "http://backend:1"})
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPatch, "/registry/heartbeat/"+svc.ID, nil)
+	rec := httptest.NewRecorder()
+	r.HandleHeartbeat(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestHandleHeartbeatUnknownIDReturnsNotFound(t *testing.T) {
+	r := New(time.Minute)
+	req := httptest.NewRequest(http.MethodPatch, "/registry/heartbeat/nonexistent", nil)
+	rec := httptest.NewRecorder()
+	r.HandleHeartbeat(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestHandleServicesListsRegistered(t *testing.T) {
+	r := New(time.Minute)
+	if _, err := r.Register(RegisterRequest{Name: "ai", URL: "http://backend:1"}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/registry/services", nil)
+	rec := httptest.NewRecorder()
+	r.HandleServices(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestURLsFiltersByName(t *testing.T) {
+	r := New(time.Minute)
+	if _, err := r.Register(RegisterRequest{Name: "ai", URL: "http://ai:1"}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if _, err := r.Register(RegisterRequest{Name: "webui", URL: "http://webui:1"}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	got := r.URLs("ai")
+	if len(got) != 1 || got[0] != "http://ai:1" {
+		t.Errorf("URLs(ai) = %v, want [http://ai:1]", got)
+	}
+}